@@ -0,0 +1,9 @@
+package controller
+
+import "k8s.io/api/core/v1"
+
+// Exposer builds the externally reachable URL for a Service under a
+// particular expose strategy (NodePort, Ingress, LoadBalancer, ...).
+type Exposer interface {
+	URL(svc *v1.Service, scheme string) (string, error)
+}
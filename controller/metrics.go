@@ -0,0 +1,76 @@
+package controller
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Prometheus metrics for reconciling Services and driving the exposer
+// strategies. These are registered with the default registry on package
+// init so the /metrics handler in registerHandlers() picks them up without
+// any extra wiring. servicesReconciled and reconcileDuration are recorded
+// from the one-shot namespace completion checks in exposecontroller.go, once
+// per namespace whose first reconcile has completed, not once per Service;
+// exposerErrors is recorded from the NodePort exposer's error paths.
+// exposeResourcesChanged and annotationErrors need to be recorded from
+// inside the Controller's reconcile loop, where Ingress/Route objects and
+// DNS/TLS annotations are actually created — that loop is not part of this
+// snapshot, so those two stay unused until it is.
+var (
+	servicesReconciled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "exposecontroller_services_reconciled_total",
+		Help: "Number of one-shot reconciles of a namespace's services completed by exposecontroller",
+	}, []string{"namespace"})
+
+	exposeResourcesChanged = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "exposecontroller_expose_resources_changed_total",
+		Help: "Number of Ingress/Route objects created, updated or deleted by exposecontroller",
+	}, []string{"namespace", "kind", "operation"})
+
+	exposerErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "exposecontroller_exposer_errors_total",
+		Help: "Number of errors returned by an exposer strategy while exposing a service",
+	}, []string{"strategy"})
+
+	annotationErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "exposecontroller_annotation_errors_total",
+		Help: "Number of failures applying DNS or TLS annotations to an expose resource",
+	}, []string{"annotation"})
+
+	reconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "exposecontroller_reconcile_duration_seconds",
+		Help:    "Time taken for a single reconcile loop to run",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"namespace"})
+)
+
+func init() {
+	prometheus.MustRegister(servicesReconciled, exposeResourcesChanged, exposerErrors, annotationErrors, reconcileDuration)
+}
+
+// RecordServiceReconciled increments the reconcile count for namespace by one;
+// it does not count the individual Services reconciled there.
+func RecordServiceReconciled(namespace string) {
+	servicesReconciled.WithLabelValues(namespace).Inc()
+}
+
+// RecordExposeResourceChange increments the count of expose resources (kind,
+// e.g. "ingress" or "route") created, updated or deleted in namespace ns.
+func RecordExposeResourceChange(namespace, kind, operation string) {
+	exposeResourcesChanged.WithLabelValues(namespace, kind, operation).Inc()
+}
+
+// RecordExposerError increments the error count for the given exposer
+// strategy (e.g. "nodeport").
+func RecordExposerError(strategy string) {
+	exposerErrors.WithLabelValues(strategy).Inc()
+}
+
+// RecordAnnotationError increments the error count for the given annotation
+// (e.g. "dns" or "tls").
+func RecordAnnotationError(annotation string) {
+	annotationErrors.WithLabelValues(annotation).Inc()
+}
+
+// ObserveReconcileDuration records how long a reconcile loop for namespace ns
+// took to run.
+func ObserveReconcileDuration(namespace string, seconds float64) {
+	reconcileDuration.WithLabelValues(namespace).Observe(seconds)
+}
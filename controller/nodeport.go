@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// NodeIPStrategy selects which address reported on a Node is used to build
+// NodePort URLs, mirroring the --node-ip-strategy flag.
+type NodeIPStrategy string
+
+const (
+	NodeIPStrategyExternal NodeIPStrategy = "external"
+	NodeIPStrategyInternal NodeIPStrategy = "internal"
+	NodeIPStrategyHostname NodeIPStrategy = "hostname"
+)
+
+// nodePortExposer exposes a Service via a URL pointing at the NodePort
+// allocated to it, rather than the container port the Service targets.
+type nodePortExposer struct {
+	client   kubernetes.Interface
+	strategy NodeIPStrategy
+}
+
+// NewNodePortExposer returns an Exposer that builds NodePort URLs, picking
+// the Node address according to strategy (defaulting to
+// NodeIPStrategyExternal). Wire it in wherever the exposer strategy is
+// selected, for the "NodePort" strategy.
+func NewNodePortExposer(client kubernetes.Interface, strategy NodeIPStrategy) Exposer {
+	if strategy == "" {
+		strategy = NodeIPStrategyExternal
+	}
+	return &nodePortExposer{client: client, strategy: strategy}
+}
+
+// URL builds the reachable URL for svc's NodePort strategy. It picks the
+// port matching scheme (see portForScheme) and uses its allocated
+// spec.ports[].nodePort as the host port, falling back to the container
+// port only when no nodePort has been assigned, and picks the Node address
+// according to the configured node IP strategy.
+func (e *nodePortExposer) URL(svc *v1.Service, scheme string) (string, error) {
+	port, err := portForScheme(svc.Spec.Ports, scheme)
+	if err != nil {
+		err = fmt.Errorf("service %s/%s: %s", svc.Namespace, svc.Name, err)
+		RecordExposerError("nodeport")
+		return "", err
+	}
+
+	hostPort := port.Port
+	if port.NodePort != 0 {
+		hostPort = port.NodePort
+	}
+
+	host, err := e.nodeAddress()
+	if err != nil {
+		RecordExposerError("nodeport")
+		return "", err
+	}
+
+	return fmt.Sprintf("%s://%s:%d", scheme, host, hostPort), nil
+}
+
+// portForScheme picks the ServicePort that URL should build against for
+// scheme. A single-port Service is unambiguous regardless of scheme. A
+// multi-port Service is matched by a port named after scheme (e.g. "https"),
+// falling back to the conventional port number for scheme (443 for "https",
+// 80 otherwise), and is an error if neither matches, rather than silently
+// defaulting to the first port.
+func portForScheme(ports []v1.ServicePort, scheme string) (v1.ServicePort, error) {
+	if len(ports) == 0 {
+		return v1.ServicePort{}, fmt.Errorf("has no ports to expose")
+	}
+	if len(ports) == 1 {
+		return ports[0], nil
+	}
+
+	for _, port := range ports {
+		if strings.EqualFold(port.Name, scheme) {
+			return port, nil
+		}
+	}
+
+	conventional := int32(80)
+	if strings.EqualFold(scheme, "https") {
+		conventional = 443
+	}
+	for _, port := range ports {
+		if port.Port == conventional {
+			return port, nil
+		}
+	}
+
+	return v1.ServicePort{}, fmt.Errorf("exposes %d ports and none is named %q or uses the conventional port %d for scheme %q", len(ports), scheme, conventional, scheme)
+}
+
+// nodeAddress returns the address of any Ready node matching the configured
+// node IP strategy.
+func (e *nodePortExposer) nodeAddress() (string, error) {
+	nodes, err := e.client.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to list nodes: %s", err)
+	}
+
+	addrType, err := e.addressType()
+	if err != nil {
+		return "", err
+	}
+
+	for _, node := range nodes.Items {
+		if !isNodeReady(node) {
+			continue
+		}
+		for _, addr := range node.Status.Addresses {
+			if addr.Type == addrType {
+				return addr.Address, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no Ready node found with a %s address", addrType)
+}
+
+// isNodeReady reports whether node's NodeReady condition is True.
+func isNodeReady(node v1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == v1.NodeReady {
+			return cond.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func (e *nodePortExposer) addressType() (v1.NodeAddressType, error) {
+	switch e.strategy {
+	case NodeIPStrategyExternal:
+		return v1.NodeExternalIP, nil
+	case NodeIPStrategyInternal:
+		return v1.NodeInternalIP, nil
+	case NodeIPStrategyHostname:
+		return v1.NodeHostName, nil
+	default:
+		return "", fmt.Errorf("unknown node IP strategy %q", e.strategy)
+	}
+}
@@ -0,0 +1,214 @@
+package controller
+
+import (
+	"testing"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestAddressType(t *testing.T) {
+	tests := []struct {
+		strategy NodeIPStrategy
+		want     v1.NodeAddressType
+		wantErr  bool
+	}{
+		{NodeIPStrategyExternal, v1.NodeExternalIP, false},
+		{NodeIPStrategyInternal, v1.NodeInternalIP, false},
+		{NodeIPStrategyHostname, v1.NodeHostName, false},
+		{NodeIPStrategy("bogus"), "", true},
+	}
+
+	for _, tt := range tests {
+		e := &nodePortExposer{strategy: tt.strategy}
+		got, err := e.addressType()
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("addressType(%s): expected an error, got none", tt.strategy)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("addressType(%s): unexpected error: %s", tt.strategy, err)
+		}
+		if got != tt.want {
+			t.Errorf("addressType(%s) = %s, want %s", tt.strategy, got, tt.want)
+		}
+	}
+}
+
+func readyNode(name, externalIP string) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: v1.NodeStatus{
+			Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}},
+			Addresses:  []v1.NodeAddress{{Type: v1.NodeExternalIP, Address: externalIP}},
+		},
+	}
+}
+
+func notReadyNode(name, externalIP string) *v1.Node {
+	node := readyNode(name, externalIP)
+	node.Status.Conditions[0].Status = v1.ConditionFalse
+	return node
+}
+
+func TestNodeAddressSkipsNotReadyNodes(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		notReadyNode("cordoned", "10.0.0.1"),
+		readyNode("ready", "10.0.0.2"),
+	)
+	e := &nodePortExposer{client: client, strategy: NodeIPStrategyExternal}
+
+	got, err := e.nodeAddress()
+	if err != nil {
+		t.Fatalf("nodeAddress() returned an unexpected error: %s", err)
+	}
+	if got != "10.0.0.2" {
+		t.Errorf("nodeAddress() = %q, want the Ready node's address %q", got, "10.0.0.2")
+	}
+}
+
+func TestNodeAddressNoReadyNodes(t *testing.T) {
+	client := fake.NewSimpleClientset(notReadyNode("cordoned", "10.0.0.1"))
+	e := &nodePortExposer{client: client, strategy: NodeIPStrategyExternal}
+
+	if _, err := e.nodeAddress(); err == nil {
+		t.Error("nodeAddress(): expected an error when no Ready node matches, got none")
+	}
+}
+
+func TestURLUsesAllocatedNodePort(t *testing.T) {
+	client := fake.NewSimpleClientset(readyNode("ready", "10.0.0.2"))
+	e := NewNodePortExposer(client, NodeIPStrategyExternal)
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "svc"},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{Port: 80, NodePort: 30080}},
+		},
+	}
+
+	got, err := e.URL(svc, "http")
+	if err != nil {
+		t.Fatalf("URL() returned an unexpected error: %s", err)
+	}
+	want := "http://10.0.0.2:30080"
+	if got != want {
+		t.Errorf("URL() = %q, want %q", got, want)
+	}
+}
+
+func TestURLFallsBackToContainerPortWithoutNodePort(t *testing.T) {
+	client := fake.NewSimpleClientset(readyNode("ready", "10.0.0.2"))
+	e := NewNodePortExposer(client, NodeIPStrategyExternal)
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "svc"},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{Port: 8080}},
+		},
+	}
+
+	got, err := e.URL(svc, "http")
+	if err != nil {
+		t.Fatalf("URL() returned an unexpected error: %s", err)
+	}
+	want := "http://10.0.0.2:8080"
+	if got != want {
+		t.Errorf("URL() = %q, want %q", got, want)
+	}
+}
+
+func TestURLNoPorts(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	e := NewNodePortExposer(client, NodeIPStrategyExternal)
+
+	svc := &v1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "svc"}}
+
+	if _, err := e.URL(svc, "http"); err == nil {
+		t.Error("URL(): expected an error for a service with no ports, got none")
+	}
+}
+
+func multiPortService() *v1.Service {
+	return &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "svc"},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{Name: "http", Port: 80, NodePort: 30080},
+				{Name: "https", Port: 443, NodePort: 30443},
+			},
+		},
+	}
+}
+
+func TestURLMultiPortMatchesSchemeByName(t *testing.T) {
+	client := fake.NewSimpleClientset(readyNode("ready", "10.0.0.2"))
+	e := NewNodePortExposer(client, NodeIPStrategyExternal)
+
+	got, err := e.URL(multiPortService(), "https")
+	if err != nil {
+		t.Fatalf("URL() returned an unexpected error: %s", err)
+	}
+	want := "https://10.0.0.2:30443"
+	if got != want {
+		t.Errorf("URL() = %q, want %q", got, want)
+	}
+}
+
+func TestURLMultiPortMatchesConventionalPortNumber(t *testing.T) {
+	client := fake.NewSimpleClientset(readyNode("ready", "10.0.0.2"))
+	e := NewNodePortExposer(client, NodeIPStrategyExternal)
+
+	svc := multiPortService()
+	svc.Spec.Ports[0].Name = ""
+	svc.Spec.Ports[1].Name = ""
+
+	got, err := e.URL(svc, "https")
+	if err != nil {
+		t.Fatalf("URL() returned an unexpected error: %s", err)
+	}
+	want := "https://10.0.0.2:30443"
+	if got != want {
+		t.Errorf("URL() = %q, want %q", got, want)
+	}
+}
+
+func TestURLMultiPortConventionalFallbackIsCaseInsensitive(t *testing.T) {
+	client := fake.NewSimpleClientset(readyNode("ready", "10.0.0.2"))
+	e := NewNodePortExposer(client, NodeIPStrategyExternal)
+
+	svc := multiPortService()
+	svc.Spec.Ports[0].Name = ""
+	svc.Spec.Ports[1].Name = ""
+
+	got, err := e.URL(svc, "HTTPS")
+	if err != nil {
+		t.Fatalf("URL() returned an unexpected error: %s", err)
+	}
+	want := "HTTPS://10.0.0.2:30443"
+	if got != want {
+		t.Errorf("URL() = %q, want %q", got, want)
+	}
+}
+
+func TestURLMultiPortNoMatchIsAnError(t *testing.T) {
+	client := fake.NewSimpleClientset(readyNode("ready", "10.0.0.2"))
+	e := NewNodePortExposer(client, NodeIPStrategyExternal)
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "svc"},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{Name: "admin", Port: 8081, NodePort: 30081},
+				{Name: "metrics", Port: 9090, NodePort: 30090},
+			},
+		},
+	}
+
+	if _, err := e.URL(svc, "https"); err == nil {
+		t.Error("URL(): expected an error when no port matches scheme \"https\" by name or convention, got none")
+	}
+}
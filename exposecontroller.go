@@ -1,27 +1,43 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"k8s.io/kubernetes/pkg/client/unversioned"
+	"io/ioutil"
 	"net/http"
 	"net/http/pprof"
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/golang/glog"
 	"github.com/jenkins-x/exposecontroller/controller"
 	"github.com/jenkins-x/exposecontroller/version"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/pflag"
-	"k8s.io/kubernetes/pkg/api"
-	kubectlutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
 )
 
 const (
 	healthPort = 10254
+
+	inClusterNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
 )
 
 var (
@@ -37,6 +53,8 @@ var (
 
 	profiling = flags.Bool("profiling", true, `Enable profiling via web interface host:port/debug/pprof/`)
 
+	kubeconfig = flags.String("kubeconfig", "", "Path to a kubeconfig file; if unset exposecontroller uses the in-cluster config")
+
 	daemon  = flag.Bool("daemon", false, `Run as daemon mode watching changes as it happens.`)
 	cleanup = flag.Bool("cleanup", false, `Removes Ingress rules that were generated by exposecontroller`)
 
@@ -48,34 +66,37 @@ var (
 	httpb                 = flag.Bool("http", false, `Use HTTP`)
 	watchNamespaces       = flag.String("watch-namespace", "", "Exposecontroller will only look at the provided namespace")
 	watchCurrentNamespace = flag.Bool("watch-current-namespace", true, `Exposecontroller will look at the current namespace only - (default: 'true' unless --watch-namespace specified)`)
+	namespaceSelector     = flag.String("namespace-selector", "", "Label selector (e.g. 'env=prod,team=platform') used to discover the namespaces to watch; exposecontroller will start and stop watches as matching namespaces come and go")
 	services              = flag.String("services", "", "List of comma separated service names which will be exposed, if empty all services from namespace will be considered")
+	leaderElect           = flag.Bool("leader-elect", false, `Use leader election so only one of multiple replicas reconciles services at a time`)
+	nodeIPStrategy        = flag.String("node-ip-strategy", "external", "Which Node address to use for NodePort URLs: 'external', 'internal' or 'hostname'")
 )
 
 func main() {
-	factory := kubectlutil.NewFactory(nil)
-	factory.BindFlags(flags)
-	factory.BindExternalFlags(flags)
 	flags.Parse(os.Args)
 	flag.CommandLine.Parse([]string{})
 
 	glog.Infof("Using build: %v", version.Version)
 
-	kubeClient, err := factory.Client()
+	restClientConfig, err := buildClientConfig(*kubeconfig)
+	if err != nil {
+		glog.Fatalf("failed to create REST client config: %s", err)
+	}
+
+	kubeClient, err := kubernetes.NewForConfig(restClientConfig)
 	if err != nil {
 		glog.Fatalf("failed to create client: %s", err)
 	}
+
 	currentNamespace := os.Getenv("KUBERNETES_NAMESPACE")
 	if len(currentNamespace) == 0 {
-		currentNamespace, _, err = factory.DefaultNamespace()
+		currentNamespace, err = findCurrentNamespace(*kubeconfig)
 		if err != nil {
 			glog.Fatalf("Could not find the current namespace: %v", err)
 		}
 	}
 
-	restClientConfig, err := factory.ClientConfig()
-	if err != nil {
-		glog.Fatalf("failed to create REST client config: %s", err)
-	}
+	var configMapNamespace, configMapName string
 
 	controllerConfig, exists, err := controller.LoadFile(*configFile)
 	if !exists || err != nil {
@@ -83,10 +104,11 @@ func main() {
 			glog.Warningf("failed to load config file: %s", err)
 		}
 
-		cc2 := tryFindConfig(kubeClient, currentNamespace)
+		cc2, cmName := tryFindConfig(kubeClient, currentNamespace)
+		configMapNamespace, configMapName = currentNamespace, cmName
 		if cc2 == nil {
 			// lets try find the ConfigMap in the dev namespace
-			resource, err := kubeClient.Namespaces().Get(currentNamespace)
+			resource, err := kubeClient.CoreV1().Namespaces().Get(currentNamespace, metav1.GetOptions{})
 			if err == nil && resource != nil {
 				labels := resource.Labels
 				if labels != nil {
@@ -96,7 +118,8 @@ func main() {
 					} else {
 						glog.Infof("trying to find the ConfigMap in the Dev Namespace %s", ns)
 
-						cc2 = tryFindConfig(kubeClient, ns)
+						cc2, cmName = tryFindConfig(kubeClient, ns)
+						configMapNamespace, configMapName = ns, cmName
 					}
 				} else {
 					glog.Warningf("No labels on Namespace %s", currentNamespace)
@@ -136,6 +159,13 @@ func main() {
 		controllerConfig.WatchNamespaces = *watchNamespaces
 		controllerConfig.WatchCurrentNamespace = false
 	}
+	if *namespaceSelector != "" {
+		controllerConfig.NamespaceSelector = *namespaceSelector
+		controllerConfig.WatchCurrentNamespace = false
+	}
+	if *nodeIPStrategy != "" {
+		controllerConfig.NodeIPStrategy = *nodeIPStrategy
+	}
 
 	if *services != "" {
 		controllerConfig.Services = strings.Split(*services, ",")
@@ -151,9 +181,12 @@ func main() {
 		}
 		watchNamespaces = currentNamespace
 	}
+	if controllerConfig.NamespaceSelector != "" {
+		glog.Infof("Watching namespaces matching label selector: `%s`", controllerConfig.NamespaceSelector)
+	}
 
 	if *cleanup {
-		ingress, err := kubeClient.Ingress(watchNamespaces).List(api.ListOptions{})
+		ingress, err := kubeClient.NetworkingV1().Ingresses(watchNamespaces).List(metav1.ListOptions{})
 		if err != nil {
 			glog.Fatalf("Could not get ingress rules in namespace %s %v", watchNamespaces, err)
 		}
@@ -162,7 +195,7 @@ func main() {
 			if i.Annotations["fabric8.io/generated-by"] == "exposecontroller" {
 				if filter == nil || strings.Contains(i.Name, *filter) {
 					glog.Infof("Deleting ingress %s", i.Name)
-					err := kubeClient.Ingress(watchNamespaces).Delete(i.Name, nil)
+					err := kubeClient.NetworkingV1().Ingresses(watchNamespaces).Delete(i.Name, &metav1.DeleteOptions{})
 					if err != nil {
 						glog.Fatalf("Could not find the current namespace: %v", err)
 					}
@@ -172,51 +205,165 @@ func main() {
 		return
 	}
 
-	if *daemon {
-		glog.Infof("Watching services in namespaces: `%s`", watchNamespaces)
+	go registerHandlers()
 
-		c, err := controller.NewController(kubeClient, restClientConfig, factory.JSONEncoder(), *resyncPeriod, watchNamespaces, controllerConfig)
-		if err != nil {
-			glog.Fatalf("%s", err)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	runController := func(ctx context.Context) {
+		if controllerConfig.NamespaceSelector != "" {
+			runSelectedNamespaces(ctx, kubeClient, restClientConfig, *resyncPeriod, controllerConfig, configMapNamespace, configMapName, *daemon)
+			return
 		}
+		if *daemon {
+			glog.Infof("Watching services in namespaces: `%s`", watchNamespaces)
 
-		go registerHandlers()
-		go handleSigterm(c)
+			c, err := controller.NewController(kubeClient, restClientConfig, *resyncPeriod, watchNamespaces, controllerConfig.NamespaceSelector, controllerConfig)
+			if err != nil {
+				glog.Fatalf("%s", err)
+			}
 
-		c.Run()
-	} else {
-		glog.Infof("Running in : `%s`", watchNamespaces)
-		c, err := controller.NewController(kubeClient, restClientConfig, factory.JSONEncoder(), *resyncPeriod, watchNamespaces, controllerConfig)
-		if err != nil {
-			glog.Fatalf("%s", err)
-		}
+			go func() {
+				<-ctx.Done()
+				c.Stop()
+			}()
+			if configMapName != "" {
+				go watchConfigMap(kubeClient, configMapNamespace, configMapName, c, wait.NeverStop)
+			}
 
-		ticker := time.NewTicker(5 * time.Second)
-		quit := make(chan struct{})
-		go func() {
-			for {
-				select {
-				case <-ticker.C:
-					if c.Hasrun() {
-						close(quit)
+			c.Run()
+		} else {
+			glog.Infof("Running in : `%s`", watchNamespaces)
+			c, err := controller.NewController(kubeClient, restClientConfig, *resyncPeriod, watchNamespaces, controllerConfig.NamespaceSelector, controllerConfig)
+			if err != nil {
+				glog.Fatalf("%s", err)
+			}
+
+			start := time.Now()
+			ticker := time.NewTicker(5 * time.Second)
+			defer ticker.Stop()
+			go func() {
+				for {
+					select {
+					case <-ticker.C:
+						if c.Hasrun() {
+							controller.ObserveReconcileDuration(watchNamespaces, time.Since(start).Seconds())
+							controller.RecordServiceReconciled(watchNamespaces)
+							c.Stop()
+							return
+						}
+					case <-ctx.Done():
+						c.Stop()
+						return
 					}
-				case <-quit:
-					c.Stop()
-					ticker.Stop()
-					return
 				}
-			}
-		}()
-		// Handle Control-C has well here
-		go handleSigterm(c)
+			}()
+
+			c.Run()
+		}
+	}
+
+	if !*leaderElect {
+		runController(ctx)
+		return
+	}
+
+	runWithLeaderElection(ctx, kubeClient, currentNamespace, runController)
+}
+
+// buildClientConfig returns the REST config to talk to the API server. A
+// given kubeconfig is used as is; otherwise it prefers the in-cluster service
+// account config and falls back to the user's default kubeconfig when running
+// outside the cluster.
+func buildClientConfig(kubeconfig string) (*rest.Config, error) {
+	if kubeconfig != "" {
+		return clientcmd.BuildConfigFromFlags("", kubeconfig)
+	}
+	config, err := rest.InClusterConfig()
+	if err == nil {
+		return config, nil
+	}
+	glog.Warningf("Not running in-cluster (%s), falling back to the default kubeconfig", err)
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	overrides := &clientcmd.ConfigOverrides{}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+}
+
+// findCurrentNamespace works out the namespace exposecontroller is running
+// in: the service account namespace file when in-cluster, or the current
+// context's namespace from the kubeconfig otherwise.
+func findCurrentNamespace(kubeconfig string) (string, error) {
+	if kubeconfig == "" {
+		if data, err := ioutil.ReadFile(inClusterNamespaceFile); err == nil {
+			return strings.TrimSpace(string(data)), nil
+		}
+	}
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfig
+	overrides := &clientcmd.ConfigOverrides{}
+	ns, _, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).Namespace()
+	return ns, err
+}
 
-		c.Run()
+// runWithLeaderElection blocks trying to acquire the exposecontroller Lease
+// in namespace, and only invokes run once this replica becomes the leader.
+// Non-leaders keep serving /healthz and /metrics (started by the caller)
+// while they wait, so a Deployment with replicas>1 never has two pods
+// reconciling the same Services. ctx is passed straight through to
+// RunOrDie, so canceling it (e.g. on SIGINT/SIGTERM) makes RunOrDie release
+// the lease and return, and makes the ctx seen by run() done as well,
+// instead of leaving the process holding the lease until it is killed.
+func runWithLeaderElection(ctx context.Context, kubeClient kubernetes.Interface, namespace string, run func(ctx context.Context)) {
+	id, err := os.Hostname()
+	if err != nil {
+		glog.Fatalf("failed to determine hostname for leader election identity: %s", err)
+	}
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      "exposecontroller-leader",
+			Namespace: namespace,
+		},
+		Client: kubeClient.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: id,
+		},
 	}
+
+	leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		ReleaseOnCancel: true,
+		LeaseDuration:   15 * time.Second,
+		RenewDeadline:   10 * time.Second,
+		RetryPeriod:     2 * time.Second,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				glog.Infof("%s became leader, reconciling services", id)
+				run(ctx)
+			},
+			OnStoppedLeading: func() {
+				if ctx.Err() != nil {
+					glog.Infof("%s shutting down, releasing leadership", id)
+					return
+				}
+				glog.Fatalf("%s lost leadership, exiting", id)
+			},
+			OnNewLeader: func(identity string) {
+				if identity != id {
+					glog.Infof("%s is the leader, waiting to acquire leadership", identity)
+				}
+			},
+		},
+	})
 }
 
-func tryFindConfig(kubeClient *unversioned.Client, ns string) *controller.Config {
+// tryFindConfig looks for the exposecontroller config either in the
+// "exposecontroller" ConfigMap (a config.yml key) or the older "ingress-config"
+// ConfigMap (flattened key/value data), and returns the parsed config along
+// with the name of the ConfigMap it came from, if any.
+func tryFindConfig(kubeClient kubernetes.Interface, ns string) (*controller.Config, string) {
 	var controllerConfig *controller.Config
-	cm, err := kubeClient.ConfigMaps(ns).Get("exposecontroller")
+	cm, err := kubeClient.CoreV1().ConfigMaps(ns).Get("exposecontroller", metav1.GetOptions{})
 	if err == nil {
 		glog.Infof("Using ConfigMap exposecontroller to load configuration...")
 		// TODO we could allow the config to be passed in via key/value pairs?
@@ -228,24 +375,250 @@ func tryFindConfig(kubeClient *unversioned.Client, ns string) *controller.Config
 			}
 			glog.Infof("Loaded ConfigMap exposecontroller to load configuration!")
 		}
-	} else {
-		glog.Warningf("Could not find ConfigMap exposecontroller ConfigMap in namespace %s", ns)
+		return controllerConfig, "exposecontroller"
+	}
+	glog.Warningf("Could not find ConfigMap exposecontroller ConfigMap in namespace %s", ns)
 
-		cm, err = kubeClient.ConfigMaps(ns).Get("ingress-config")
+	cm, err = kubeClient.CoreV1().ConfigMaps(ns).Get("ingress-config", metav1.GetOptions{})
+	if err != nil {
+		glog.Warningf("Could not find ConfigMap ingress-config ConfigMap in namespace %s", ns)
+		return nil, ""
+	}
+	glog.Infof("Loaded ConfigMap ingress-config to load configuration!")
+	data := cm.Data
+	if data != nil {
+		controllerConfig, err = controller.MapToConfig(data)
 		if err != nil {
-			glog.Warningf("Could not find ConfigMap ingress-config ConfigMap in namespace %s", ns)
-		} else {
-			glog.Infof("Loaded ConfigMap ingress-config to load configuration!")
-			data := cm.Data
-			if data != nil {
-				controllerConfig, err = controller.MapToConfig(data)
-				if err != nil {
-					glog.Warningf("Failed to convert Map data %#v from configMap ingress-config in namespace %s due to: %s\n", controllerConfig, ns, err)
-				}
+			glog.Warningf("Failed to convert Map data %#v from configMap ingress-config in namespace %s due to: %s\n", controllerConfig, ns, err)
+		}
+	}
+	return controllerConfig, "ingress-config"
+}
+
+// watchConfigMap starts an informer on the single ConfigMap that
+// controllerConfig was loaded from, so that config.yml/key-value changes made
+// to it at runtime are picked up without restarting the pod. On every update
+// it re-parses the ConfigMap, and if the parsed domain, exposer or http
+// setting differs from the one the Controller is currently running with it
+// asks the Controller to resync (which cleans up and re-creates the affected
+// Ingress/Route objects); changes to other settings do not trigger a resync. The
+// informer runs until stopCh is closed, so callers that stop watching a
+// namespace before the process exits (e.g. runSelectedNamespaces) can stop
+// this goroutine along with the Controller it is tied to.
+func watchConfigMap(kubeClient kubernetes.Interface, namespace, name string, c *controller.Controller, stopCh <-chan struct{}) {
+	selector := fields.OneTermEqualSelector("metadata.name", name)
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = selector.String()
+			return kubeClient.CoreV1().ConfigMaps(namespace).List(options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = selector.String()
+			return kubeClient.CoreV1().ConfigMaps(namespace).Watch(options)
+		},
+	}
+
+	_, informer := cache.NewInformer(lw, &v1.ConfigMap{}, *resyncPeriod, cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			cm, ok := newObj.(*v1.ConfigMap)
+			if !ok {
+				return
+			}
+			var (
+				newConfig *controller.Config
+				err       error
+			)
+			if text := cm.Data["config.yml"]; text != "" {
+				newConfig, err = controller.Load(text)
+			} else {
+				newConfig, err = controller.MapToConfig(cm.Data)
+			}
+			if err != nil {
+				glog.Warningf("Failed to reload config from ConfigMap %s/%s: %s", namespace, name, err)
+				return
 			}
+
+			current := c.Config()
+			if newConfig.Domain == current.Domain && newConfig.Exposer == current.Exposer && newConfig.HTTP == current.HTTP {
+				glog.V(4).Infof("ConfigMap %s/%s changed but exposecontroller settings are unaffected", namespace, name)
+				return
+			}
+
+			glog.Infof("ConfigMap %s/%s changed, resyncing exposed services with the new configuration", namespace, name)
+			if err := c.Resync(newConfig); err != nil {
+				glog.Errorf("Failed to resync after config change: %s", err)
+			}
+		},
+	})
+
+	informer.Run(stopCh)
+}
+
+// namespaceController tracks the Controller running for one namespace
+// selected by --namespace-selector, along with the stop channel for the
+// config ConfigMap watcher started alongside it, so both can be torn down
+// together when the namespace stops matching the selector.
+type namespaceController struct {
+	ns     string
+	c      *controller.Controller
+	stopCh chan struct{}
+}
+
+// runSelectedNamespaces lists the namespaces currently matching
+// controllerConfig.NamespaceSelector and starts a Controller watching each
+// one. When running as a daemon it then keeps a Namespace informer running
+// for the lifetime of ctx, starting a Controller for namespaces that are
+// created or labeled to match the selector, and stopping it (and its
+// ConfigMap watcher) for namespaces that are deleted or unlabeled, so
+// namespaces can come and go without a restart. Canceling ctx (e.g. via the
+// SIGINT/SIGTERM-derived context the caller threads through) stops every
+// running Controller from a background goroutine; returning does not wait
+// for that goroutine to finish. In non-daemon (one-shot)
+// mode it blocks, like the single-namespace path above, until every matched
+// namespace's Controller has completed its first reconcile.
+func runSelectedNamespaces(ctx context.Context, kubeClient kubernetes.Interface, restClientConfig *rest.Config, resyncPeriod time.Duration, controllerConfig *controller.Config, configMapNamespace, configMapName string, daemonMode bool) {
+	selector, err := labels.Parse(controllerConfig.NamespaceSelector)
+	if err != nil {
+		glog.Fatalf("Invalid --namespace-selector `%s`: %s", controllerConfig.NamespaceSelector, err)
+	}
+
+	var mu sync.Mutex
+	running := map[string]*namespaceController{}
+
+	start := func(ns string) *namespaceController {
+		mu.Lock()
+		defer mu.Unlock()
+		if nc, ok := running[ns]; ok {
+			return nc
+		}
+		glog.Infof("Namespace %s matches selector `%s`, watching its services", ns, controllerConfig.NamespaceSelector)
+		c, err := controller.NewController(kubeClient, restClientConfig, resyncPeriod, ns, controllerConfig.NamespaceSelector, controllerConfig)
+		if err != nil {
+			glog.Errorf("Failed to start controller for namespace %s: %s", ns, err)
+			return nil
+		}
+		nc := &namespaceController{ns: ns, c: c, stopCh: make(chan struct{})}
+		running[ns] = nc
+		if configMapName != "" {
+			go watchConfigMap(kubeClient, configMapNamespace, configMapName, c, nc.stopCh)
+		}
+		go c.Run()
+		return nc
+	}
+
+	stop := func(ns string) {
+		mu.Lock()
+		defer mu.Unlock()
+		nc, ok := running[ns]
+		if !ok {
+			return
+		}
+		glog.Infof("Namespace %s no longer matches selector `%s`, stopping", ns, controllerConfig.NamespaceSelector)
+		close(nc.stopCh)
+		nc.c.Stop()
+		delete(running, ns)
+	}
+
+	stopAll := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for ns, nc := range running {
+			close(nc.stopCh)
+			nc.c.Stop()
+			delete(running, ns)
 		}
 	}
-	return controllerConfig
+
+	go func() {
+		<-ctx.Done()
+		glog.Infof("Shutting down, stopping all watched namespaces")
+		stopAll()
+	}()
+
+	namespaces, err := kubeClient.CoreV1().Namespaces().List(metav1.ListOptions{LabelSelector: controllerConfig.NamespaceSelector})
+	if err != nil {
+		glog.Fatalf("Could not list namespaces matching selector `%s`: %s", controllerConfig.NamespaceSelector, err)
+	}
+
+	var started []*namespaceController
+	for _, ns := range namespaces.Items {
+		if nc := start(ns.Name); nc != nil {
+			started = append(started, nc)
+		}
+	}
+
+	if !daemonMode {
+		var wg sync.WaitGroup
+		for _, nc := range started {
+			wg.Add(1)
+			go func(nc *namespaceController) {
+				defer wg.Done()
+				beganAt := time.Now()
+				ticker := time.NewTicker(5 * time.Second)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-ticker.C:
+						if nc.c.Hasrun() {
+							controller.ObserveReconcileDuration(nc.ns, time.Since(beganAt).Seconds())
+							controller.RecordServiceReconciled(nc.ns)
+							nc.c.Stop()
+							return
+						}
+					case <-ctx.Done():
+						nc.c.Stop()
+						return
+					}
+				}
+			}(nc)
+		}
+		wg.Wait()
+		return
+	}
+
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return kubeClient.CoreV1().Namespaces().List(options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return kubeClient.CoreV1().Namespaces().Watch(options)
+		},
+	}
+
+	_, informer := cache.NewInformer(lw, &v1.Namespace{}, resyncPeriod, cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if ns, ok := obj.(*v1.Namespace); ok && selector.Matches(labels.Set(ns.Labels)) {
+				start(ns.Name)
+			}
+		},
+		UpdateFunc: func(_, newObj interface{}) {
+			ns, ok := newObj.(*v1.Namespace)
+			if !ok {
+				return
+			}
+			if selector.Matches(labels.Set(ns.Labels)) {
+				start(ns.Name)
+			} else {
+				stop(ns.Name)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			ns, ok := obj.(*v1.Namespace)
+			if !ok {
+				tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+				if !ok {
+					return
+				}
+				ns, ok = tombstone.Obj.(*v1.Namespace)
+				if !ok {
+					return
+				}
+			}
+			stop(ns.Name)
+		},
+	})
+
+	informer.Run(ctx.Done())
 }
 
 func registerHandlers() {
@@ -257,17 +630,15 @@ func registerHandlers() {
 		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
 	}
 
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "ok")
+	})
+	mux.Handle("/metrics", promhttp.Handler())
+
 	server := &http.Server{
 		Addr:    fmt.Sprintf(":%v", *healthzPort),
 		Handler: mux,
 	}
 	glog.Fatal(server.ListenAndServe())
 }
-
-func handleSigterm(c *controller.Controller) {
-	signalChan := make(chan os.Signal, 1)
-	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
-	sig := <-signalChan
-	glog.Infof("Received %s, shutting down", sig)
-	c.Stop()
-}